@@ -3,15 +3,17 @@ from __future__ import annotations
 
 import tkinter as tk
 from dataclasses import dataclass, field
-from typing import List
+from typing import List, Optional
 from urllib.parse import urlparse, urlunparse
 
 import ttkbootstrap as ttk
 from tkinter import messagebox
 
 from driver.selenium import BrowserClient
-from utils.diagnostics import DiagnosticsService
+from utils.diagnostics import DiagnosticsReport, DiagnosticsService, read_recent_errors
+from utils.filesystem import open_in_file_browser
 from utils.logger import Logger
+from utils.settings import SettingsStore
 
 
 @dataclass
@@ -34,7 +36,17 @@ class ScrapeGoatApp:
     def __init__(self) -> None:
         self.logger = Logger(log_file="app.log")
         self.browser_client = BrowserClient()
-        self.diagnostics_service = DiagnosticsService(self.browser_client)
+        self.settings_store: Optional[SettingsStore] = None
+        settings_error = ""
+        try:
+            self.settings_store = SettingsStore(logger=self.logger)
+        except RuntimeError as error:
+            # Keep running without persistence; the settings screen shows why.
+            settings_error = str(error)
+            self.logger.error(f"Settings will not be persisted: {error}")
+        self.diagnostics_service = DiagnosticsService(
+            self.browser_client, self.settings_store, settings_error
+        )
 
         self.root = tk.Tk()
         self.root.title("ScrapeGoat Browser")
@@ -52,6 +64,7 @@ class ScrapeGoatApp:
         self.settings_screen = SettingsScreen(
             master=self.notebook,
             diagnostics_service=self.diagnostics_service,
+            settings_store=self.settings_store,
             logger=self.logger,
         )
 
@@ -185,10 +198,19 @@ class ActivityScreen(ttk.Frame):
 class SettingsScreen(ttk.Frame):
     """Settings screen displaying diagnostics and environment information."""
 
-    def __init__(self, master, diagnostics_service: DiagnosticsService, logger: Logger):
+    def __init__(
+        self,
+        master,
+        diagnostics_service: DiagnosticsService,
+        settings_store: Optional[SettingsStore],
+        logger: Logger,
+    ):
         super().__init__(master)
         self._diagnostics_service = diagnostics_service
+        self._settings_store = settings_store
         self.logger = logger
+        self.warning_text = tk.StringVar(master=self)
+        self._report: DiagnosticsReport | None = None
 
         self.system_tree = ttk.Treeview(self, columns=("Property", "Value"), show="headings", height=6)
         self.system_tree.heading("Property", text="Property")
@@ -208,6 +230,9 @@ class SettingsScreen(ttk.Frame):
         ttk.Label(self, text="Diagnostics", font=("TkDefaultFont", 12, "bold")).pack(
             anchor="w", padx=10, pady=(10, 5)
         )
+        ttk.Label(self, textvariable=self.warning_text, bootstyle="danger", wraplength=700).pack(
+            anchor="w", padx=10
+        )
         self.system_tree.pack(fill="x", padx=10)
 
         ttk.Label(self, text="Capacity Estimates", font=("TkDefaultFont", 11, "bold")).pack(
@@ -215,9 +240,20 @@ class SettingsScreen(ttk.Frame):
         )
         self.capacity_tree.pack(fill="x", padx=10)
 
-        ttk.Button(self, text="Refresh diagnostics", command=self.refresh).pack(
-            anchor="e", padx=10, pady=15
+        button_frame = ttk.Frame(self)
+        button_frame.pack(fill="x", padx=10, pady=15)
+        ttk.Button(button_frame, text="Refresh diagnostics", command=self.refresh).pack(
+            side="right"
         )
+        ttk.Button(
+            button_frame, text="Fix settings permissions", command=self._fix_permissions
+        ).pack(side="right", padx=(0, 5))
+        ttk.Button(button_frame, text="Copy report", command=self._copy_report).pack(
+            side="right", padx=(0, 5)
+        )
+        ttk.Button(
+            button_frame, text="Open settings folder", command=self._reveal_settings
+        ).pack(side="right", padx=(0, 5))
 
         self.binary_info = tk.Text(self, height=6, state="disabled", wrap="word")
         self.binary_info.pack(fill="both", expand=True, padx=10, pady=(0, 10))
@@ -230,10 +266,49 @@ class SettingsScreen(ttk.Frame):
             messagebox.showerror("Diagnostics error", "Unable to collect diagnostics.")
             return
 
+        self._report = report
+        self.warning_text.set(report.settings_warning())
         self._populate_tree(self.system_tree, report.system_info_rows())
         self._populate_tree(self.capacity_tree, report.capacity_rows())
         self._populate_binary_info(report)
 
+    def _store_unavailable(self) -> bool:
+        if self._settings_store is not None:
+            return False
+        messagebox.showwarning("Settings", "Settings are not being saved; see the diagnostics.")
+        return True
+
+    def _fix_permissions(self) -> None:
+        if self._store_unavailable():
+            return
+        try:
+            self._settings_store.fix_permissions()
+        except OSError as error:
+            self.logger.error(f"Failed to fix settings permissions: {error}")
+            messagebox.showerror("Permissions error", "Unable to update settings permissions.")
+            return
+        self.refresh()
+
+    def _copy_report(self) -> None:
+        if self._report is None:
+            messagebox.showwarning(
+                "Diagnostic report", "Refresh diagnostics before copying the report."
+            )
+            return
+        text = self._report.to_text(read_recent_errors(self.logger.log_file))
+        self.clipboard_clear()
+        self.clipboard_append(text)
+        messagebox.showinfo("Diagnostic report", "The report was copied to the clipboard.")
+
+    def _reveal_settings(self) -> None:
+        if self._store_unavailable():
+            return
+        try:
+            open_in_file_browser(str(self._settings_store.path.parent))
+        except OSError as error:
+            self.logger.error(f"Failed to open settings folder: {error}")
+            messagebox.showerror("Settings folder", str(error))
+
     def _populate_tree(self, tree: ttk.Treeview, rows: List[tuple[str, str]]) -> None:
         for item in tree.get_children():
             tree.delete(item)
@@ -243,7 +318,7 @@ class SettingsScreen(ttk.Frame):
     def _populate_binary_info(self, report) -> None:
         self.binary_info.configure(state="normal")
         self.binary_info.delete("1.0", tk.END)
-        for key, value in report.binary_rows():
+        for key, value in report.binary_rows() + report.settings_rows():
             self.binary_info.insert(tk.END, f"{key}: {value}\n")
         self.binary_info.configure(state="disabled")
 
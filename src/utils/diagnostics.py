@@ -1,11 +1,15 @@
 """Diagnostics helpers for the desktop application."""
 from __future__ import annotations
 
+import re
+from collections import deque
 from dataclasses import asdict, dataclass
-from typing import Dict, List
+from pathlib import Path
+from typing import Dict, Iterable, List, Optional, Tuple
 
 from driver.selenium import BrowserClient
 from utils.helpers import BrowserCapacity, calculate_max_browsers_or_tabs
+from utils.settings import SettingsStore, redact_url
 
 
 @dataclass(frozen=True)
@@ -26,6 +30,17 @@ class DiagnosticsReport:
 
     capacity: BrowserCapacity
     binaries: List[BinaryDiagnostic]
+    settings_path: str = ""
+    settings_location: str = ""
+    settings_location_failures: Tuple[str, ...] = ()
+    # Why no settings store could be created; settings are not persisted when set.
+    settings_error: str = ""
+    settings_permissions: str = ""
+    settings_read_only: bool = False
+    settings_updated_at: str = ""
+    llm_base_url: str = ""
+    llm_model: str = ""
+    llm_api_key_configured: bool = False
 
     def system_info_rows(self) -> List[tuple[str, str]]:
         info = self.capacity.system_info.to_dict()
@@ -49,12 +64,99 @@ class DiagnosticsReport:
             rows.append((f"{binary.name} version", binary.version))
         return rows
 
+    def settings_rows(self) -> List[tuple[str, str]]:
+        if self.settings_error:
+            return [("Settings", f"not persisted: {self.settings_error}")]
+        if not self.settings_path:
+            return []
+        rows = [
+            ("Settings path", self.settings_path),
+            ("Settings location", self.settings_location),
+        ]
+        if self.settings_location_failures:
+            skipped = "; ".join(self.settings_location_failures)
+            rows.append(("Settings locations skipped", skipped))
+        return rows + [
+            ("Settings permissions", self.settings_permissions),
+            ("Settings writable", "no (read-only directory)" if self.settings_read_only else "yes"),
+            ("Settings last updated", self.settings_updated_at or "never"),
+        ]
+
+    def settings_warning(self) -> str:
+        """A message the settings screen should display prominently, or "" when all is well."""
+        if self.settings_error:
+            return f"Settings will not be saved: {self.settings_error}"
+        if self.settings_read_only:
+            return (
+                "Settings cannot be saved because the config directory is read-only; "
+                "the existing settings are still used."
+            )
+        return ""
+
+    def to_text(self, recent_errors: Iterable[str] = ()) -> str:
+        """Render a shareable report with secrets masked and the home directory replaced by ~."""
+        sections = [
+            ("System", self.system_info_rows()),
+            ("Capacity", self.capacity_rows()),
+            ("Binaries", self.binary_rows()),
+            ("Settings", self.settings_rows()),
+            (
+                "LLM",
+                [
+                    ("Base URL", redact_url(self.llm_base_url) or "not configured"),
+                    ("Model", self.llm_model or "not configured"),
+                    ("API key", "configured" if self.llm_api_key_configured else "not configured"),
+                ],
+            ),
+        ]
+        lines: List[str] = []
+        for title, rows in sections:
+            lines.append(f"[{title}]")
+            lines.extend(f"{key}: {value}" for key, value in rows)
+            lines.append("")
+        lines.append("[Recent errors]")
+        lines.extend(recent_errors or ["none"])
+        return _scrub_home("\n".join(lines))
+
+
+def read_recent_errors(log_file: str, limit: int = 20) -> List[str]:
+    """Return the last ``limit`` ERROR or CRITICAL lines from the application log."""
+    try:
+        with open(log_file, encoding="utf-8", errors="replace") as handle:
+            matches = (
+                line.rstrip()
+                for line in handle
+                if " - ERROR - " in line or " - CRITICAL - " in line
+            )
+            return list(deque(matches, maxlen=limit))
+    except OSError:
+        return []
+
+
+def _scrub_home(text: str) -> str:
+    try:
+        home = str(Path.home())
+    except RuntimeError:
+        return text
+    if home in ("", "/", "\\"):
+        return text
+    # Whole path components only, so a home of /home/al leaves /home/alice untouched.
+    pattern = re.compile(r"(?<![\w./\\-])" + re.escape(home) + r"(?![\w.-])")
+    return pattern.sub("~", text)
+
 
 class DiagnosticsService:
     """Collects diagnostics information to feed the UI."""
 
-    def __init__(self, client: BrowserClient):
+    def __init__(
+        self,
+        client: BrowserClient,
+        settings_store: Optional[SettingsStore] = None,
+        settings_error: str = "",
+    ):
         self._client = client
+        self._settings_store = settings_store
+        self._settings_error = settings_error
 
     def collect(self) -> DiagnosticsReport:
         capacity = calculate_max_browsers_or_tabs()
@@ -90,4 +192,35 @@ class DiagnosticsService:
                     version=str(error),
                 )
             )
-        return DiagnosticsReport(capacity=capacity, binaries=binaries)
+        settings_path = ""
+        settings_location = ""
+        settings_location_failures: Tuple[str, ...] = ()
+        settings_permissions = ""
+        settings_read_only = False
+        settings_updated_at = ""
+        llm_settings = None
+        if self._settings_store is not None:
+            settings_path = str(self._settings_store.path)
+            settings_location = self._settings_store.location
+            settings_location_failures = tuple(self._settings_store.location_failures)
+            settings_read_only = not self._settings_store.can_persist()[0]
+            llm_settings = self._settings_store.load()
+            settings_updated_at = llm_settings.updated_at
+            try:
+                settings_permissions = self._settings_store.check_permissions()
+            except OSError as error:
+                settings_permissions = f"Unable to check: {error}"
+        return DiagnosticsReport(
+            capacity=capacity,
+            binaries=binaries,
+            settings_path=settings_path,
+            settings_location=settings_location,
+            settings_location_failures=settings_location_failures,
+            settings_error=self._settings_error,
+            settings_permissions=settings_permissions,
+            settings_read_only=settings_read_only,
+            settings_updated_at=settings_updated_at,
+            llm_base_url=llm_settings.base_url if llm_settings else "",
+            llm_model=llm_settings.model if llm_settings else "",
+            llm_api_key_configured=bool(llm_settings and llm_settings.api_key),
+        )
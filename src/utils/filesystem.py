@@ -1,6 +1,7 @@
 import os
 import sys
 import shutil
+import subprocess
 
 def get_resource_path(relative_path: str) -> str:
     """
@@ -86,3 +87,24 @@ def delete_file(path: str) -> bool:
     except OSError as e:
         print(f"Error deleting file {abs_path}: {e}")
         return False
+
+def open_in_file_browser(path: str) -> None:
+    """
+    Open the platform file browser at the given directory. If the directory
+    does not exist yet, its nearest existing parent is opened instead.
+    
+    :param path: The directory to reveal.
+    :raises OSError: If the platform is unsupported or the browser cannot be launched.
+    """
+    target = os.path.abspath(path)
+    while not os.path.isdir(target) and os.path.dirname(target) != target:
+        target = os.path.dirname(target)
+
+    if sys.platform == "win32":
+        os.startfile(target)
+    elif sys.platform == "darwin":
+        subprocess.Popen(["open", target])
+    elif sys.platform.startswith("linux"):
+        subprocess.Popen(["xdg-open", target])
+    else:
+        raise OSError(f"Opening a file browser is not supported on {sys.platform}.")
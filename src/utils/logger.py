@@ -37,6 +37,7 @@ class Logger:
         return cls._instance
 
     def _initialize_logger(self, log_level: int, log_file: str) -> None:
+        self.log_file = log_file
         self.logger_instance = logging.getLogger(__name__)
         self.logger_instance.setLevel(log_level)
 
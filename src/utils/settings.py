@@ -1,10 +1,66 @@
 from __future__ import annotations
 
+import contextlib
+import errno
 import json
 import os
-from dataclasses import dataclass, asdict
+import re
+import stat
+import sys
+import tempfile
+from dataclasses import dataclass, asdict, fields, replace
+from datetime import datetime, timezone
 from pathlib import Path
-from typing import Any, Dict
+from typing import Any, Callable, Dict, Iterator, List, Tuple
+from urllib.parse import parse_qsl, urlsplit, urlunsplit
+
+if os.name == "nt":
+    import msvcrt
+else:
+    import fcntl
+
+from utils.logger import Logger
+
+CONFIG_PATH_ENV = "COPILOT_ENIGMA_CONFIG"
+_SCHEME_PATTERN = re.compile(r"^[A-Za-z][A-Za-z0-9+.-]*://")
+
+
+class SettingsReadOnlyError(OSError):
+    """Raised when settings cannot be saved because the config directory or file is read-only."""
+
+    def __init__(self, path: Path, is_directory: bool = True) -> None:
+        kind = "config directory" if is_directory else "settings file"
+        super().__init__(f"{kind} is read-only: {path}")
+        self.path = path
+        self.is_directory = is_directory
+
+
+class SettingsConflictError(RuntimeError):
+    """Raised when settings changed on disk since the caller loaded them."""
+
+    def __init__(self, expected: int, actual: int) -> None:
+        super().__init__(
+            f"settings changed elsewhere (expected version {expected}, found {actual}); "
+            "reload before saving"
+        )
+        self.expected = expected
+        self.actual = actual
+
+
+@dataclass(frozen=True)
+class FieldError:
+    """A validation problem with a single settings field, named as in LLMSettings."""
+
+    field: str
+    message: str
+
+
+class SettingsValidationError(ValueError):
+    """Raised by SettingsStore.save with one entry per invalid field for forms to highlight."""
+
+    def __init__(self, errors: List[FieldError]) -> None:
+        super().__init__("; ".join(f"{error.field}: {error.message}" for error in errors))
+        self.errors = errors
 
 
 @dataclass
@@ -12,13 +68,120 @@ class LLMSettings:
     base_url: str = ""
     api_key: str = ""
     model: str = ""
+    # Maintained by SettingsStore.save; values supplied by callers are ignored.
+    created_at: str = ""
+    updated_at: str = ""
+    version: int = 0
+
+
+SECRET_FIELDS = frozenset({"api_key"})
+# URL fields may embed credentials (user:password@ or ?api_key=) and are logged redacted.
+_URL_FIELDS = frozenset({"base_url"})
+# Fields the store maintains itself; they change on every save and are not worth auditing.
+_UNAUDITED_FIELDS = frozenset({"created_at", "updated_at", "version"})
+
+
+@dataclass(frozen=True)
+class FieldChange:
+    """A single settings field that differs between two versions, with secrets masked."""
+
+    field: str
+    old: str
+    new: str
+
+    def __str__(self) -> str:
+        if self.field in SECRET_FIELDS:
+            return f"{self.field}: changed"
+        return f"{self.field}: {self.old!r} -> {self.new!r}"
+
+
+def diff_settings(old: LLMSettings, new: LLMSettings) -> List[FieldChange]:
+    """List the fields that differ between ``old`` and ``new``, never including secret values."""
+    changes: List[FieldChange] = []
+    for field in fields(LLMSettings):
+        if field.name in _UNAUDITED_FIELDS:
+            continue
+        before, after = getattr(old, field.name), getattr(new, field.name)
+        if before == after:
+            continue
+        if field.name in SECRET_FIELDS:
+            before, after = "***", "***"
+        elif field.name in _URL_FIELDS:
+            before, after = redact_url(before), redact_url(after)
+        changes.append(FieldChange(field.name, str(before), str(after)))
+    return changes
+
+
+def redact_url(url: str) -> str:
+    """Drop any user:password part and mask query values, which gateways use for keys."""
+    if not url:
+        return ""
+    try:
+        parts = urlsplit(url)
+        query = "&".join(
+            f"{key}=***" for key, _ in parse_qsl(parts.query, keep_blank_values=True)
+        )
+    except ValueError:
+        return "(unparseable URL hidden)"
+    host = parts.netloc.rpartition("@")[2]
+    return urlunsplit((parts.scheme, host, parts.path, query, ""))
+
+
+def normalize_base_url(url: str) -> str:
+    """Trim whitespace, default to https and collapse trailing slashes on an API base URL.
+
+    Only the path loses its trailing slashes; query and fragment are kept as typed.
+    Raises ValueError when the URL cannot be parsed or has no host.
+    """
+    url = url.strip()
+    if not url:
+        return ""
+    if not _SCHEME_PATTERN.match(url):
+        url = f"https://{url}"
+    parts = urlsplit(url)
+    if not parts.netloc:
+        raise ValueError(f"The API base URL '{url}' does not include a host.")
+    return urlunsplit(parts._replace(path=parts.path.rstrip("/")))
+
+
+def validate_settings(settings: LLMSettings) -> List[FieldError]:
+    """Check every user-editable field and return all problems found, in field order."""
+    errors: List[FieldError] = []
+    try:
+        normalize_base_url(settings.base_url)
+    except ValueError as error:
+        errors.append(FieldError("base_url", str(error)))
+    # Pasted keys and model names often carry a stray newline, which breaks request headers.
+    for name in ("api_key", "model"):
+        if any(character.isspace() for character in getattr(settings, name)):
+            errors.append(FieldError(name, "must not contain spaces or line breaks"))
+    return errors
+
+
+def _parse_version(value: Any) -> int:
+    # A missing or hand-edited version must not discard the rest of the file.
+    try:
+        return max(int(value), 0)
+    except (TypeError, ValueError):
+        return 0
 
 
 class SettingsStore:
     """Persist lightweight application settings to the user configuration directory."""
 
-    def __init__(self, config_path: Path | None = None) -> None:
-        self.path = config_path or self._default_path()
+    def __init__(self, config_path: Path | None = None, logger: Logger | None = None) -> None:
+        self._logger = logger
+        override = os.getenv(CONFIG_PATH_ENV)
+        # Candidate locations that could not be used, as "<location>: <reason>".
+        self.location_failures: List[str] = []
+        if config_path is not None:
+            self.path, self.location = config_path, "custom path"
+        elif override:
+            self.path, self.location = self._override_path(override), CONFIG_PATH_ENV
+        else:
+            self.path, self.location = self._default_path(self.location_failures)
+        # Reads keep working from the existing file; only saves are refused.
+        self.read_only = not self.is_writable()
 
     def load(self) -> LLMSettings:
         if not self.path.exists():
@@ -30,18 +193,202 @@ class SettingsStore:
                 base_url=data.get("base_url", ""),
                 api_key=data.get("api_key", ""),
                 model=data.get("model", ""),
+                created_at=data.get("created_at", ""),
+                updated_at=data.get("updated_at", ""),
+                version=_parse_version(data.get("version")),
             )
         except (json.JSONDecodeError, OSError):  # pragma: no cover - defensive guard
             return LLMSettings()
 
-    def save(self, settings: LLMSettings) -> None:
-        self.path.parent.mkdir(parents=True, exist_ok=True)
-        payload: Dict[str, Any] = asdict(settings)
-        self.path.write_text(json.dumps(payload, indent=2))
+    def save(
+        self, settings: LLMSettings, expected_version: int | None = None
+    ) -> LLMSettings:
+        """Persist settings, stamping creation and update times, and return what was written.
+
+        Changed fields are logged with secrets masked and URL credentials removed. Each save
+        bumps ``version``; pass the version the caller loaded as ``expected_version`` to get
+        SettingsConflictError instead of overwriting a newer save from another window.
+
+        The base URL is stored normalized. Raises SettingsValidationError, a ValueError listing
+        every invalid field, before anything is written, and SettingsReadOnlyError when the
+        config directory or the settings file cannot be written.
+        """
+        errors = validate_settings(settings)
+        if errors:
+            raise SettingsValidationError(errors)
+        now = datetime.now(timezone.utc).isoformat(timespec="milliseconds")
+        try:
+            self.path.parent.mkdir(mode=0o700, parents=True, exist_ok=True)
+            with self._exclusive_lock():
+                previous = self.load()
+                if expected_version is not None and expected_version != previous.version:
+                    raise SettingsConflictError(expected_version, previous.version)
+                stored = replace(
+                    settings,
+                    base_url=normalize_base_url(settings.base_url),
+                    created_at=previous.created_at or now,
+                    updated_at=now,
+                    version=previous.version + 1,
+                )
+                payload: Dict[str, Any] = asdict(stored)
+                self._write_private(json.dumps(payload, indent=2))
+        except OSError as error:
+            if not isinstance(error, PermissionError) and error.errno != errno.EROFS:
+                raise
+            # A writable directory means only the settings file itself was made read-only.
+            if error.errno != errno.EROFS and os.access(self.path.parent, os.W_OK):
+                raise SettingsReadOnlyError(self.path, is_directory=False) from error
+            self.read_only = True
+            raise SettingsReadOnlyError(self.path.parent) from error
+        self.read_only = False
+
+        changes = diff_settings(previous, stored)
+        if self._logger is not None and changes:
+            summary = "; ".join(str(change) for change in changes)
+            self._logger.info(f"Settings saved to {self.path}: {summary}")
+        return stored
+
+    @contextlib.contextmanager
+    def _exclusive_lock(self) -> Iterator[None]:
+        # Held across load, version check and write so that saves from other windows or
+        # processes queue up rather than overwrite each other. Readers never take it.
+        lock_path = self.path.with_name(f"{self.path.name}.lock")
+        descriptor = os.open(lock_path, os.O_RDWR | os.O_CREAT, 0o600)
+        try:
+            if os.name == "nt":
+                msvcrt.locking(descriptor, msvcrt.LK_LOCK, 1)
+            else:
+                fcntl.flock(descriptor, fcntl.LOCK_EX)
+            try:
+                yield
+            finally:
+                if os.name == "nt":
+                    msvcrt.locking(descriptor, msvcrt.LK_UNLCK, 1)
+        finally:
+            # Closing the descriptor also releases the flock on POSIX.
+            os.close(descriptor)
+
+    def _write_private(self, text: str) -> None:
+        # Write a sibling temp file and rename it over settings.json, so readers never wait on
+        # the disk or see a partial file, and concurrent saves cannot interleave their bytes.
+        # mkstemp creates the file owner-only (it holds the API key) regardless of the umask.
+        descriptor, temp_name = tempfile.mkstemp(
+            dir=self.path.parent, prefix=".settings-", suffix=".tmp"
+        )
+        try:
+            with os.fdopen(descriptor, "w", encoding="utf-8") as handle:
+                handle.write(text)
+                handle.flush()
+                os.fsync(handle.fileno())
+            os.replace(temp_name, self.path)
+        except BaseException:
+            with contextlib.suppress(OSError):
+                os.unlink(temp_name)
+            raise
+
+    def is_writable(self) -> bool:
+        """Create the settings directory if needed and prove it accepts a new file.
+
+        settings.json itself is never touched; a throwaway temp file is created and removed.
+        """
+        try:
+            self.path.parent.mkdir(mode=0o700, parents=True, exist_ok=True)
+            with tempfile.NamedTemporaryFile(dir=self.path.parent, prefix=".write-test-"):
+                pass
+        except OSError:
+            return False
+        return True
+
+    def can_persist(self) -> Tuple[bool, str]:
+        """Re-check writability, refreshing ``read_only``, and describe the outcome.
+
+        Returns the resolved settings path on success, or why saving would fail.
+        """
+        self.read_only = not self.is_writable()
+        if self.read_only:
+            return False, str(SettingsReadOnlyError(self.path.parent))
+        return True, str(self.path)
+
+    def check_permissions(self) -> str:
+        """Describe whether the settings file and its directory are private to the user."""
+        if os.name == "nt":
+            return "n/a"
+        if not self.path.parent.is_dir():
+            return "not created"
+
+        problems = []
+        if self.path.exists():
+            file_mode = stat.S_IMODE(self.path.stat().st_mode)
+            if file_mode & 0o077:
+                problems.append(f"file is {file_mode:04o} (expected 0600)")
+        dir_mode = stat.S_IMODE(self.path.parent.stat().st_mode)
+        if dir_mode & 0o077:
+            problems.append(f"directory is {dir_mode:04o} (expected 0700)")
+        return "; ".join(problems) if problems else "ok"
+
+    def fix_permissions(self) -> None:
+        """Restrict the settings file to 0600 and its directory to 0700."""
+        if os.name == "nt" or not self.path.parent.is_dir():
+            return
+        self.path.parent.chmod(0o700)
+        if self.path.exists():
+            self.path.chmod(0o600)
+
+    @staticmethod
+    def _override_path(value: str) -> Path:
+        # A directory (existing, or written with a trailing separator) holds settings.json.
+        path = Path(value).expanduser()
+        if path.is_dir() or value.endswith(("/", os.sep)):
+            return path / "settings.json"
+        return path
 
     @staticmethod
-    def _default_path() -> Path:
+    def _default_path(failures: List[str]) -> Tuple[Path, str]:
+        """Pick the first usable settings location, appending each rejected one to ``failures``.
+
+        Raises RuntimeError listing every reason when no location can be used.
+        """
+        for resolve, location in SettingsStore._candidate_dirs():
+            try:
+                return resolve() / "settings.json", location
+            except (OSError, RuntimeError) as error:
+                failures.append(f"{location}: {error}")
+        raise RuntimeError(
+            "No usable settings location could be determined: " + "; ".join(failures)
+        )
+
+    @staticmethod
+    def _candidate_dirs() -> Iterator[Tuple[Callable[[], Path], str]]:
+        def xdg_config_dir() -> Path:
+            value = os.getenv("XDG_CONFIG_HOME", "")
+            if not value:
+                raise RuntimeError("XDG_CONFIG_HOME is not set")
+            if not os.path.isabs(value):
+                raise RuntimeError(f"XDG_CONFIG_HOME is not an absolute path: {value}")
+            return Path(value) / "copliot-enigma"
+
+        def user_config_dir() -> Path:
+            # Same lookup order as Go's os.UserConfigDir.
+            if os.name == "nt":
+                return Path(os.getenv("APPDATA") or Path.home()) / "Copliot Enigma"
+            if os.getenv("XDG_CONFIG_HOME"):
+                return xdg_config_dir()
+            return Path.home() / ".config" / "copliot-enigma"
+
+        def executable_dir() -> Path:
+            if not getattr(sys, "frozen", False):
+                raise RuntimeError("only used by packaged builds")
+            directory = Path(sys.executable).resolve().parent
+            if not os.access(directory, os.W_OK):
+                raise PermissionError(f"{directory} is not writable")
+            return directory / "config"
+
+        def temp_dir() -> Path:
+            return Path(tempfile.gettempdir()) / "copliot-enigma"
+
+        yield user_config_dir, "user config directory"
         if os.name == "nt":
-            base = Path(os.getenv("APPDATA", Path.home()))
-            return base / "Copliot Enigma" / "settings.json"
-        return Path.home() / ".config" / "copliot-enigma" / "settings.json"
+            # The user config directory already covers XDG_CONFIG_HOME everywhere else.
+            yield xdg_config_dir, "XDG_CONFIG_HOME"
+        yield executable_dir, "executable directory"
+        yield temp_dir, "temporary directory"